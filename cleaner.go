@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// identifierPattern 限定 TableName/CompareField 只能是合法的 SQL 标识符，
+// 防止 YAML/JSON 配置中拼接进恶意片段造成 SQL 注入
+var identifierPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// defaultCleanupBatchSize 是未指定 BatchSize 时单次删除的行数上限
+const defaultCleanupBatchSize = 500
+
+// CleanupRule 描述一条表清理规则：按 Spec 周期性地删除 TableName 中 CompareField 早于
+// (当前时间 - Interval) 的记录
+type CleanupRule struct {
+	TableName    string `yaml:"table_name"`
+	CompareField string `yaml:"compare_field"`
+	Interval     string `yaml:"interval"` // time.ParseDuration 格式，如 "720h"
+	Spec         string `yaml:"spec"`     // cron 表达式，决定清理任务的触发频率
+	BatchSize    int    `yaml:"batch_size"`
+	SoftDelete   bool   `yaml:"soft_delete"` // true 时更新 deleted_at 实现软删除，否则物理 DELETE
+	DryRun       bool   `yaml:"dry_run"`     // true 时只统计命中行数并记录日志，不做任何写操作
+}
+
+// cleanupConfig 对应清理规则 YAML/JSON 配置文件的顶层结构
+type cleanupConfig struct {
+	Rules []CleanupRule `yaml:"rules"`
+}
+
+// LoadCleanupRules 从 path 指向的 YAML/JSON 配置文件加载清理规则，并为每条规则注册一个 cron 条目
+func (cs *CronScheduler) LoadCleanupRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取清理规则配置 %s 失败: %v", path, err)
+	}
+
+	var cfg cleanupConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("解析清理规则配置 %s 失败: %v", path, err)
+	}
+
+	for _, rule := range cfg.Rules {
+		if err := cs.registerCleanupRule(rule); err != nil {
+			return fmt.Errorf("注册清理规则 %s 失败: %v", rule.TableName, err)
+		}
+	}
+	return nil
+}
+
+// registerCleanupRule 解析单条规则的 Interval 并注册对应的 cron 条目
+func (cs *CronScheduler) registerCleanupRule(rule CleanupRule) error {
+	if !identifierPattern.MatchString(rule.TableName) {
+		return fmt.Errorf("非法的 table_name %q", rule.TableName)
+	}
+	if !identifierPattern.MatchString(rule.CompareField) {
+		return fmt.Errorf("非法的 compare_field %q", rule.CompareField)
+	}
+
+	interval, err := time.ParseDuration(rule.Interval)
+	if err != nil {
+		return fmt.Errorf("解析 interval %q 失败: %v", rule.Interval, err)
+	}
+	batchSize := rule.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCleanupBatchSize
+	}
+
+	_, err = cs.cron.AddFunc(rule.Spec, func() {
+		cs.runCleanup(rule, interval, batchSize)
+	})
+	return err
+}
+
+// runCleanup 执行一次清理：DryRun 时只统计命中行数，否则按 batchSize 分批删除（或软删除）直到命中行数不足一批
+func (cs *CronScheduler) runCleanup(rule CleanupRule, interval time.Duration, batchSize int) {
+	cutoff := time.Now().Add(-interval)
+	condition := fmt.Sprintf("%s < ?", rule.CompareField)
+	if rule.SoftDelete {
+		// 排除已经软删除的行，否则每一轮都会重新命中同一批已处理的行，RowsAffected
+		// 永远等于 batchSize，下面的分批循环就不会结束
+		condition += " AND deleted_at IS NULL"
+	}
+
+	if rule.DryRun {
+		var count int64
+		if err := cs.db.Table(rule.TableName).Where(condition, cutoff).Count(&count).Error; err != nil {
+			cs.logger.Error("清理表统计失败", "table", rule.TableName, "err", err)
+			return
+		}
+		cs.logger.Info("清理表（dry-run）", "table", rule.TableName, "hit_rows", count, "compare_field", rule.CompareField, "cutoff", cutoff)
+		return
+	}
+
+	var total int64
+	for {
+		tx := cs.db.Table(rule.TableName).Where(condition, cutoff).Limit(batchSize)
+
+		var result *gorm.DB
+		if rule.SoftDelete {
+			result = tx.Update("deleted_at", time.Now())
+		} else {
+			result = tx.Delete(nil)
+		}
+		if result.Error != nil {
+			cs.logger.Error("清理表失败", "table", rule.TableName, "err", result.Error)
+			return
+		}
+
+		total += result.RowsAffected
+		if result.RowsAffected < int64(batchSize) {
+			break
+		}
+	}
+
+	cs.logger.Info("清理表完成", "table", rule.TableName, "rows", total, "compare_field", rule.CompareField, "cutoff", cutoff)
+}
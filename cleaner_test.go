@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newCleanupTestDB 建立一个内存 sqlite 库，手工建表以模拟清理规则面对的任意业务表
+func newCleanupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE cleanup_rows (id INTEGER PRIMARY KEY AUTOINCREMENT, created_at DATETIME, deleted_at DATETIME)`).Error; err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	return db
+}
+
+// seedCleanupRows 插入 n 行 created_at 均为 createdAt 的测试数据
+func seedCleanupRows(t *testing.T, db *gorm.DB, n int, createdAt time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := db.Exec(`INSERT INTO cleanup_rows (created_at) VALUES (?)`, createdAt).Error; err != nil {
+			t.Fatalf("插入测试数据失败: %v", err)
+		}
+	}
+}
+
+// TestRunCleanup_SoftDeleteTerminates 复现并防止回归：SoftDelete 规则下，命中行数大于等于
+// batchSize 时，WHERE 条件必须排除已处理的行，否则分批循环会一直重复命中同一批行
+func TestRunCleanup_SoftDeleteTerminates(t *testing.T) {
+	db := newCleanupTestDB(t)
+	seedCleanupRows(t, db, 10, time.Now().Add(-48*time.Hour))
+
+	cs := &CronScheduler{db: db, logger: NoopLogger{}}
+	rule := CleanupRule{TableName: "cleanup_rows", CompareField: "created_at", SoftDelete: true}
+
+	done := make(chan struct{})
+	go func() {
+		cs.runCleanup(rule, 24*time.Hour, 3)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runCleanup 在 SoftDelete 模式下未能在 5s 内结束，分批循环疑似没有终止")
+	}
+
+	var remaining int64
+	if err := db.Table("cleanup_rows").Where("deleted_at IS NULL").Count(&remaining).Error; err != nil {
+		t.Fatalf("统计剩余行失败: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("期望全部 10 行被软删除，实际仍有 %d 行未处理", remaining)
+	}
+}
+
+// TestRunCleanup_HardDeleteTerminates 确认物理删除路径（命中行数同样跨越多个 batch）正常结束
+func TestRunCleanup_HardDeleteTerminates(t *testing.T) {
+	db := newCleanupTestDB(t)
+	seedCleanupRows(t, db, 7, time.Now().Add(-48*time.Hour))
+
+	cs := &CronScheduler{db: db, logger: NoopLogger{}}
+	rule := CleanupRule{TableName: "cleanup_rows", CompareField: "created_at"}
+
+	done := make(chan struct{})
+	go func() {
+		cs.runCleanup(rule, 24*time.Hour, 2)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runCleanup 在物理删除模式下未能在 5s 内结束")
+	}
+
+	var remaining int64
+	if err := db.Table("cleanup_rows").Count(&remaining).Error; err != nil {
+		t.Fatalf("统计剩余行失败: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("期望全部 7 行被删除，实际仍剩 %d 行", remaining)
+	}
+}
+
+// TestRegisterCleanupRule_RejectsInvalidIdentifiers 确认 table_name/compare_field 不是合法
+// 标识符时直接拒绝注册，防止拼接进恶意 SQL 片段
+func TestRegisterCleanupRule_RejectsInvalidIdentifiers(t *testing.T) {
+	cases := []CleanupRule{
+		{TableName: "users; DROP TABLE users --", CompareField: "created_at", Interval: "24h", Spec: "0 0 * * * *"},
+		{TableName: "users", CompareField: "created_at = 1 OR 1=1", Interval: "24h", Spec: "0 0 * * * *"},
+	}
+
+	cs := &CronScheduler{db: newCleanupTestDB(t), logger: NoopLogger{}, cron: cron.New(cron.WithSeconds())}
+	for _, rule := range cases {
+		if err := cs.registerCleanupRule(rule); err == nil {
+			t.Fatalf("期望非法标识符 %+v 被拒绝，实际注册成功", rule)
+		}
+	}
+}
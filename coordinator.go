@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Session 代表一次分布式锁的租约会话，由具体的 Coordinator 实现（etcd/Redis）返回
+type Session interface {
+	// Done 在租约到期或被撤销时关闭，调度器据此判断是否需要提前终止任务
+	Done() <-chan struct{}
+	// Release 主动释放会话持有的锁，交还给协调后端
+	Release(ctx context.Context) error
+}
+
+// Coordinator 抽象分布式协调后端，使同一份任务表可以被多个调度器副本共享，但每次只有一个副本真正触发任务
+//
+// 典型实现基于 etcd（concurrency.Election/Session）或 Redis（SET NX PX + 续约协程），
+// Campaign 内部应包含租约的心跳续约循环，保证持有者崩溃时锁会自动释放。
+type Coordinator interface {
+	// Campaign 尝试获取 key 对应的锁，在获得锁之前阻塞；ctx 被取消时返回 error
+	Campaign(ctx context.Context, key string) (Session, error)
+	// Resign 主动放弃当前持有的所有会话，通常在调度器退出时调用
+	Resign() error
+}
+
+// WithCoordinator 注入分布式协调后端：设置后，每次任务触发都会先竞争 "task:<id>:<scheduledAt>" 锁，
+// 只有竞争到锁的副本才会真正执行该次任务，从而避免多副本部署下的任务重复触发
+func WithCoordinator(coordinator Coordinator) Option {
+	return func(cs *CronScheduler) {
+		cs.coordinator = coordinator
+	}
+}
+
+// WithLocalIP 显式指定本副本的 IP，用于匹配 Task.SpecifyIP 亲和性过滤；不设置时调度器会尝试自动探测
+func WithLocalIP(ip string) Option {
+	return func(cs *CronScheduler) {
+		cs.localIP = ip
+	}
+}
+
+// detectLocalIP 尝试探测本机的出站 IP，探测失败时返回空字符串（此时设置了 SpecifyIP 的任务都不会在本副本上执行）
+func detectLocalIP() string {
+	conn, err := net.Dial("udp", "8.8.8.8:80")
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String()
+}
+
+// matchesAffinity 判断任务是否允许在本副本上运行：SpecifyIP 为空表示不限制
+func (cs *CronScheduler) matchesAffinity(task Task) bool {
+	return task.SpecifyIP == "" || task.SpecifyIP == cs.localIP
+}
+
+// scheduledTimeFor 返回 taskID 本次触发对应的调度时刻，取自 cron 库为该 entry 维护的
+// Prev（即上一次计算出的 Next，在触发开始时被固化下来），所有副本据此算出的值相同，
+// 可以安全地用作分布式锁 key 的一部分。taskIDs 中尚未来得及记录该 entry 时（理论上只有
+// 极窄的注册竞态窗口）退化为 time.Now()
+func (cs *CronScheduler) scheduledTimeFor(taskID uint) time.Time {
+	cs.taskMu.Lock()
+	entry, ok := cs.taskIDs[taskID]
+	cs.taskMu.Unlock()
+	if !ok {
+		return time.Now()
+	}
+
+	prev := cs.cron.Entry(entry.EntryID).Prev
+	if prev.IsZero() {
+		return time.Now()
+	}
+	return prev
+}
+
+// runWithCoordination 在执行 fn 前先竞争分布式锁（如果配置了 Coordinator），
+// 竞争失败或本副本不满足 IP 亲和性时直接跳过本次触发。scheduledAt 必须是所有副本对同一次
+// 触发都能独立算出相同值的调度时刻（如 cron.Entry.Prev），而不能用各副本自己在 fn 即将
+// 执行时读到的 time.Now()：goroutine 被唤醒的时刻会因时钟偏移、GC 暂停等原因跨越整秒边界，
+// 不同副本可能为同一次调度算出不同的锁 key，导致都竞选成功、都执行了该次任务
+func (cs *CronScheduler) runWithCoordination(task Task, traceID string, scheduledAt time.Time, fn func()) {
+	if !cs.matchesAffinity(task) {
+		cs.logger.Info("任务跳过：不匹配本副本 IP", "task_id", task.ID, "task_name", task.Name,
+			"trace_id", traceID, "specify_ip", task.SpecifyIP, "local_ip", cs.localIP)
+		return
+	}
+
+	if cs.coordinator == nil {
+		fn()
+		return
+	}
+
+	key := fmt.Sprintf("task:%d:%d", task.ID, scheduledAt.Unix())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := cs.coordinator.Campaign(ctx, key)
+	if err != nil {
+		cs.logger.Warn("竞选锁失败，本次跳过", "task_id", task.ID, "task_name", task.Name,
+			"trace_id", traceID, "lock_key", key, "err", err)
+		return
+	}
+	defer session.Release(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-session.Done():
+		cs.logger.Warn("执行期间租约失效，锁已被撤销", "task_id", task.ID, "task_name", task.Name,
+			"trace_id", traceID, "lock_key", key)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// fakeSession 是测试用的 Session 实现，永不过期、Release 直接返回 nil
+type fakeSession struct{}
+
+func (fakeSession) Done() <-chan struct{}         { return nil }
+func (fakeSession) Release(context.Context) error { return nil }
+
+// recordingCoordinator 记录每次 Campaign 收到的 key，供测试断言
+type recordingCoordinator struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (c *recordingCoordinator) Campaign(_ context.Context, key string) (Session, error) {
+	c.mu.Lock()
+	c.keys = append(c.keys, key)
+	c.mu.Unlock()
+	return fakeSession{}, nil
+}
+
+func (c *recordingCoordinator) Resign() error { return nil }
+
+func TestMatchesAffinity(t *testing.T) {
+	cs := &CronScheduler{localIP: "10.0.0.1"}
+
+	cases := []struct {
+		name string
+		task Task
+		want bool
+	}{
+		{"无亲和性限制", Task{SpecifyIP: ""}, true},
+		{"IP 匹配本机", Task{SpecifyIP: "10.0.0.1"}, true},
+		{"IP 不匹配本机", Task{SpecifyIP: "10.0.0.2"}, false},
+	}
+	for _, tc := range cases {
+		if got := cs.matchesAffinity(tc.task); got != tc.want {
+			t.Errorf("%s: matchesAffinity() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRunWithCoordination_LockKeyUsesScheduledAt 确认锁 key 由调用方传入的 scheduledAt 派生，
+// 而不是 runWithCoordination 内部各自读取的 time.Now()：同一个 scheduledAt 无论调用多少次，
+// 必须拼出同一个 key，这是多副本去重的前提
+func TestRunWithCoordination_LockKeyUsesScheduledAt(t *testing.T) {
+	coordinator := &recordingCoordinator{}
+	cs := &CronScheduler{logger: NoopLogger{}, coordinator: coordinator}
+	task := Task{ID: 42}
+	scheduledAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cs.runWithCoordination(task, "trace", scheduledAt, func() {})
+		}()
+	}
+	wg.Wait()
+
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+	if len(coordinator.keys) != 2 {
+		t.Fatalf("期望两次 Campaign 调用，实际 %d 次", len(coordinator.keys))
+	}
+	if coordinator.keys[0] != coordinator.keys[1] {
+		t.Fatalf("两次对同一 scheduledAt 的调用算出了不同的锁 key: %q != %q", coordinator.keys[0], coordinator.keys[1])
+	}
+	if want := fmt.Sprintf("task:%d:%d", task.ID, scheduledAt.Unix()); coordinator.keys[0] != want {
+		t.Fatalf("期望锁 key 由 scheduledAt 派生为 %q，实际 %q", want, coordinator.keys[0])
+	}
+}
+
+// TestScheduledTimeFor_FallsBackWhenUnscheduled 确认 taskIDs 中尚无记录时回退到 time.Now()，
+// 而不是返回零值时间（会让所有副本都拼出 "task:<id>:0" 这个无意义的 key）
+func TestScheduledTimeFor_FallsBackWhenUnscheduled(t *testing.T) {
+	cs := &CronScheduler{
+		cron:    cron.New(cron.WithSeconds()),
+		taskIDs: make(map[uint]taskEntry),
+	}
+
+	before := time.Now()
+	got := cs.scheduledTimeFor(999)
+	if got.Before(before) || got.After(time.Now()) {
+		t.Fatalf("期望回退到当前时间附近，实际得到 %v", got)
+	}
+}
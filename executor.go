@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Executor 定义任务执行器的统一接口，不同 Type 的任务通过各自的实现执行
+type Executor interface {
+	Execute(ctx context.Context, task Task) (output string, err error)
+}
+
+// shellArgs 是 Args 列针对 shell 任务的 JSON 结构
+type shellArgs struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Timeout string   `json:"timeout"` // time.ParseDuration 格式，覆盖 DefaultTimeout
+}
+
+// ShellExecutor 通过 exec.Cmd 执行 shell 命令，捕获 stdout/stderr 并支持超时控制
+type ShellExecutor struct {
+	DefaultTimeout time.Duration
+}
+
+func (e ShellExecutor) Execute(ctx context.Context, task Task) (string, error) {
+	var args shellArgs
+	if task.Args != "" {
+		if err := json.Unmarshal([]byte(task.Args), &args); err != nil {
+			return "", fmt.Errorf("解析 shell 任务参数失败: %v", err)
+		}
+	}
+	if args.Command == "" {
+		args.Command = task.Program
+	}
+
+	timeout := e.DefaultTimeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	if args.Timeout != "" {
+		if d, err := time.ParseDuration(args.Timeout); err == nil {
+			timeout = d
+		}
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(execCtx, args.Command, args.Args...)
+	if traceID, ok := TraceIDFromContext(ctx); ok {
+		cmd.Env = append(os.Environ(), "TASK_TRACE_ID="+traceID)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("执行 shell 命令失败: %v, stderr: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// httpArgs 是 Args 列针对 http 任务的 JSON 结构
+type httpArgs struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// HTTPExecutor 通过 HTTP 请求执行任务，请求失败时按 MaxRetries 重试
+type HTTPExecutor struct {
+	Client     *http.Client
+	MaxRetries int
+	RetryWait  time.Duration
+}
+
+func (e HTTPExecutor) Execute(ctx context.Context, task Task) (string, error) {
+	var args httpArgs
+	if task.Args != "" {
+		if err := json.Unmarshal([]byte(task.Args), &args); err != nil {
+			return "", fmt.Errorf("解析 HTTP 任务参数失败: %v", err)
+		}
+	}
+	if args.URL == "" {
+		args.URL = task.Program
+	}
+	if args.Method == "" {
+		args.Method = http.MethodGet
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	retryWait := e.RetryWait
+	if retryWait == 0 {
+		retryWait = time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= e.MaxRetries; attempt++ {
+		output, err := e.doRequest(ctx, client, args)
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+		if attempt < e.MaxRetries {
+			time.Sleep(retryWait)
+		}
+	}
+	return "", fmt.Errorf("执行 HTTP 任务失败（已重试 %d 次）: %v", e.MaxRetries, lastErr)
+}
+
+func (e HTTPExecutor) doRequest(ctx context.Context, client *http.Client, args httpArgs) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, args.Method, args.URL, bytes.NewBufferString(args.Body))
+	if err != nil {
+		return "", fmt.Errorf("构造 HTTP 请求失败: %v", err)
+	}
+	for k, v := range args.Headers {
+		req.Header.Set(k, v)
+	}
+	if traceID, ok := TraceIDFromContext(ctx); ok {
+		req.Header.Set("X-Trace-Id", traceID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("HTTP 请求返回状态码 %d: %s", resp.StatusCode, buf.String())
+	}
+	return buf.String(), nil
+}
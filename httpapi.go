@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// TaskInfo 是管理接口返回的任务视图，在 Task 基础上附带下一次触发时间
+type TaskInfo struct {
+	Task
+	Next time.Time `json:"next,omitempty"`
+}
+
+// ListTasks 返回数据库中的全部任务，附带每个任务下一次触发时间（未调度/已暂停时为零值）
+func (cs *CronScheduler) ListTasks() ([]TaskInfo, error) {
+	var tasks []Task
+	if err := cs.db.Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("查询任务列表失败: %v", err)
+	}
+
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		infos = append(infos, TaskInfo{Task: task, Next: cs.nextRun(task.ID)})
+	}
+	return infos, nil
+}
+
+// nextRun 返回指定任务下一次触发时间，任务未调度（如已暂停）时返回零值
+func (cs *CronScheduler) nextRun(taskID uint) time.Time {
+	cs.taskMu.Lock()
+	entry, ok := cs.taskIDs[taskID]
+	cs.taskMu.Unlock()
+	if !ok {
+		return time.Time{}
+	}
+	return cs.cron.Entry(entry.EntryID).Next
+}
+
+// UpdateTask 更新任务的 Name/Program/Cron/Type/Args；若该任务当前已调度，
+// 会先移除旧的 cron 条目再按更新后的任务重新注册
+func (cs *CronScheduler) UpdateTask(taskID uint, name, program, cronExpr, taskType, args string) error {
+	// 与调度器（cron.WithSeconds）使用同一种含秒的 6 段格式校验
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	if _, err := parser.Parse(cronExpr); err != nil {
+		return fmt.Errorf("cron 表达式 %q 不合法: %v", cronExpr, err)
+	}
+
+	var task Task
+	if err := cs.db.First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("任务 %d 不存在: %v", taskID, err)
+	}
+
+	task.Name = name
+	task.Program = program
+	task.Cron = cronExpr
+	task.Type = taskType
+	task.Args = args
+	if err := cs.db.Save(&task).Error; err != nil {
+		return fmt.Errorf("更新任务 %d 失败: %v", taskID, err)
+	}
+
+	cs.taskMu.Lock()
+	entry, scheduled := cs.taskIDs[taskID]
+	cs.taskMu.Unlock()
+	if scheduled {
+		cs.cron.Remove(entry.EntryID)
+		if err := cs.scheduleTask(task); err != nil {
+			return fmt.Errorf("重新调度任务 %d 失败: %v", taskID, err)
+		}
+	}
+	return nil
+}
+
+// PauseTask 从调度器中移除任务的 cron 条目但保留数据库记录，之后可通过 ResumeTask 恢复；
+// 任务当前未调度时视为已暂停，直接返回成功
+func (cs *CronScheduler) PauseTask(taskID uint) error {
+	cs.taskMu.Lock()
+	entry, ok := cs.taskIDs[taskID]
+	if ok {
+		cs.cron.Remove(entry.EntryID)
+		delete(cs.taskIDs, taskID)
+	}
+	cs.taskMu.Unlock()
+	return nil
+}
+
+// ResumeTask 重新调度一个已暂停的任务
+func (cs *CronScheduler) ResumeTask(taskID uint) error {
+	var task Task
+	if err := cs.db.First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("任务 %d 不存在: %v", taskID, err)
+	}
+	return cs.scheduleTask(task)
+}
+
+// TriggerNow 立即执行一次任务，不等待其 cron 触发时间；仍记录 TaskRun 并遵守该任务的
+// 重试、超时和并发策略
+func (cs *CronScheduler) TriggerNow(taskID uint) error {
+	var task Task
+	if err := cs.db.First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("任务 %d 不存在: %v", taskID, err)
+	}
+	traceID := newTraceID()
+	// 手动触发没有"调度时刻"的概念，直接用当前时间即可：TriggerNow 本身就是一次性的单点调用，
+	// 不存在多副本各自独立推导出同一次调度时刻、再去重的问题
+	go cs.runWithCoordination(task, traceID, time.Now(), func() { cs.executeWithTracking(task, traceID) })
+	return nil
+}
+
+// WithHTTPMiddleware 注入包裹 HTTPHandler 返回值的中间件，典型用途是鉴权/授权。
+// HTTPHandler 自身不做任何身份校验，见其文档中的警告
+func WithHTTPMiddleware(mw func(http.Handler) http.Handler) Option {
+	return func(cs *CronScheduler) {
+		cs.httpMiddleware = mw
+	}
+}
+
+// HTTPHandler 返回任务管理 API 的 http.Handler，可直接 http.ListenAndServe，也可以挂载到
+// gin/echo 等框架下（如 router.Any("/tasks/*", gin.WrapH(handler))），
+// 使本模块从一个演示程序变为可嵌入服务的组件
+//
+// POST /tasks 可以创建 Type: "shell" 的任务，其 Program/Args 会被 ShellExecutor 原样传给
+// exec.CommandContext——不受保护地暴露这组路由等于把主机的远程代码执行能力开放给任何能访问到
+// 它的人。因此必须先通过 WithHTTPMiddleware 注入鉴权中间件，未配置时 HTTPHandler 直接返回错误
+// 而不是退化成无鉴权的 mux，避免"忘记接鉴权"在部署时被悄悄放行
+func (cs *CronScheduler) HTTPHandler() (http.Handler, error) {
+	if cs.httpMiddleware == nil {
+		return nil, fmt.Errorf("HTTPHandler 需要先通过 WithHTTPMiddleware 注入鉴权中间件，否则会暴露未授权的任务管理接口（含 shell 执行能力）")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tasks", cs.handleTasks)
+	mux.HandleFunc("/tasks/", cs.handleTask)
+	mux.HandleFunc("/cron/validate", cs.handleValidateCron)
+
+	return cs.httpMiddleware(mux), nil
+}
+
+// handleTasks 处理 /tasks 集合资源：GET 列出全部任务，POST 创建新任务
+func (cs *CronScheduler) handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tasks, err := cs.ListTasks()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, tasks)
+	case http.MethodPost:
+		var req struct {
+			Name    string `json:"name"`
+			Program string `json:"program"`
+			Cron    string `json:"cron"`
+			Type    string `json:"type"`
+			Args    string `json:"args"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := cs.AddTask(req.Name, req.Program, req.Cron, req.Type, req.Args); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTask 处理 /tasks/{id} 及其子资源：{id}、{id}/pause、{id}/resume、{id}/trigger、{id}/runs
+func (cs *CronScheduler) handleTask(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/tasks/")
+	parts := strings.SplitN(strings.Trim(rest, "/"), "/", 2)
+	id64, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("非法的任务 ID %q", parts[0]))
+		return
+	}
+	id := uint(id64)
+
+	if len(parts) == 1 {
+		cs.handleTaskItem(w, r, id)
+		return
+	}
+
+	switch parts[1] {
+	case "pause":
+		cs.handleAction(w, r, func() error { return cs.PauseTask(id) })
+	case "resume":
+		cs.handleAction(w, r, func() error { return cs.ResumeTask(id) })
+	case "trigger":
+		cs.handleAction(w, r, func() error { return cs.TriggerNow(id) })
+	case "runs":
+		cs.handleRuns(w, r, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// handleTaskItem 处理单个任务资源：GET 查询详情，PUT 更新，DELETE 删除
+func (cs *CronScheduler) handleTaskItem(w http.ResponseWriter, r *http.Request, id uint) {
+	switch r.Method {
+	case http.MethodGet:
+		var task Task
+		if err := cs.db.First(&task, id).Error; err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, TaskInfo{Task: task, Next: cs.nextRun(id)})
+	case http.MethodPut:
+		var req struct {
+			Name    string `json:"name"`
+			Program string `json:"program"`
+			Cron    string `json:"cron"`
+			Type    string `json:"type"`
+			Args    string `json:"args"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := cs.UpdateTask(id, req.Name, req.Program, req.Cron, req.Type, req.Args); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if err := cs.RemoveTask(id); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAction 统一处理 pause/resume/trigger 这类"执行一个动作、返回成功或失败"的 POST 接口
+func (cs *CronScheduler) handleAction(w http.ResponseWriter, r *http.Request, action func() error) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := action(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRuns 处理 /tasks/{id}/runs：返回该任务的执行历史，可通过 ?limit= 限制条数
+func (cs *CronScheduler) handleRuns(w http.ResponseWriter, r *http.Request, id uint) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	runs, err := cs.ListRuns(id, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// handleValidateCron 校验请求体中的 cron 表达式是否合法（cron.ParseStandard），
+// 供管理界面在提交前做即时校验。ParseStandard 只接受不含秒的 5 段格式，
+// 而调度器按含秒的 6 段格式解析任务的 Cron，两者判定结果不一致
+func (cs *CronScheduler) handleValidateCron(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Spec string `json:"spec"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Valid bool   `json:"valid"`
+		Error string `json:"error,omitempty"`
+	}{Valid: true}
+	if _, err := cron.ParseStandard(req.Spec); err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeJSON 将 v 序列化为 JSON 并写入响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 将 err 包装为 {"error": "..."} 写入响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
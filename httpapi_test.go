@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHTTPHandler_AppliesMiddleware 确认配置了 WithHTTPMiddleware 后，HTTPHandler 返回的
+// http.Handler 会先经过该中间件——这是鉴权类中间件得以生效的前提
+func TestHTTPHandler_AppliesMiddleware(t *testing.T) {
+	cs := NewCronScheduler(nil, WithHTTPMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}))
+
+	handler, err := cs.HTTPHandler()
+	if err != nil {
+		t.Fatalf("配置了中间件时 HTTPHandler 不应报错: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("期望中间件拦截请求并返回 401，实际状态码 %d", rec.Code)
+	}
+}
+
+// TestHTTPHandler_RequiresMiddleware 未配置 WithHTTPMiddleware 时 HTTPHandler 必须报错，
+// 而不是退化返回一个无鉴权的 mux——否则暴露 Type: "shell" 的任务等于开放未授权的远程代码执行
+func TestHTTPHandler_RequiresMiddleware(t *testing.T) {
+	cs := NewCronScheduler(nil)
+
+	handler, err := cs.HTTPHandler()
+	if err == nil {
+		t.Fatal("未配置中间件时 HTTPHandler 应返回错误，而不是可用的 handler")
+	}
+	if handler != nil {
+		t.Fatal("未配置中间件时 HTTPHandler 不应返回非 nil 的 handler")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Logger 是调度器内部统一使用的日志接口，取代直接的 log.Printf/fmt.Printf 调用，
+// 便于调用方把调度器事件接入自己的可观测性体系。kv 以 key1, value1, key2, value2... 的
+// 形式传入结构化字段，实现者可以忽略它们或原样转发给底层日志库
+type Logger interface {
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// WithLogger 注入自定义 Logger，替换调度器默认的标准库 log 输出
+func WithLogger(logger Logger) Option {
+	return func(cs *CronScheduler) {
+		cs.logger = logger
+	}
+}
+
+// NoopLogger 丢弃所有日志，适合测试或不需要调度器日志输出的场景
+type NoopLogger struct{}
+
+func (NoopLogger) Info(string, ...interface{})  {}
+func (NoopLogger) Warn(string, ...interface{})  {}
+func (NoopLogger) Error(string, ...interface{}) {}
+
+// stdLogger 是未调用 WithLogger 时的默认实现，行为与改造前的 log.Printf 调用等价
+type stdLogger struct{}
+
+func (stdLogger) Info(msg string, kv ...interface{})  { log.Printf("INFO %s %s", msg, formatKV(kv)) }
+func (stdLogger) Warn(msg string, kv ...interface{})  { log.Printf("WARN %s %s", msg, formatKV(kv)) }
+func (stdLogger) Error(msg string, kv ...interface{}) { log.Printf("ERROR %s %s", msg, formatKV(kv)) }
+
+// formatKV 把 key1, value1, key2, value2... 拼成 "key1=value1 key2=value2" 形式，落单的 key 原样输出
+func formatKV(kv []interface{}) string {
+	s := ""
+	for i := 0; i < len(kv); i += 2 {
+		if i > 0 {
+			s += " "
+		}
+		if i+1 < len(kv) {
+			s += fmt.Sprintf("%v=%v", kv[i], kv[i+1])
+		} else {
+			s += fmt.Sprintf("%v", kv[i])
+		}
+	}
+	return s
+}
+
+// SlogLogger 把 Logger 接口适配到标准库 log/slog
+type SlogLogger struct {
+	L *slog.Logger
+}
+
+func (s SlogLogger) Info(msg string, kv ...interface{})  { s.L.Info(msg, kv...) }
+func (s SlogLogger) Warn(msg string, kv ...interface{})  { s.L.Warn(msg, kv...) }
+func (s SlogLogger) Error(msg string, kv ...interface{}) { s.L.Error(msg, kv...) }
+
+// ZapLogger 把 Logger 接口适配到 uber-go/zap 的 SugaredLogger
+type ZapLogger struct {
+	L *zap.SugaredLogger
+}
+
+func (z ZapLogger) Info(msg string, kv ...interface{})  { z.L.Infow(msg, kv...) }
+func (z ZapLogger) Warn(msg string, kv ...interface{})  { z.L.Warnw(msg, kv...) }
+func (z ZapLogger) Error(msg string, kv ...interface{}) { z.L.Errorw(msg, kv...) }
+
+// traceIDKey 是 trace ID 在 context 中的键类型
+type traceIDKey struct{}
+
+// withTraceID 把 traceID 绑定到 ctx 上，随 ctx 一路传给 Executor
+func withTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext 从 ctx 中取出当前任务执行的 trace ID，Executor 实现可用它关联日志、
+// 透传给下游系统（如作为 HTTP 请求头）
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(traceIDKey{}).(string)
+	return traceID, ok
+}
+
+// newTraceID 为一次任务触发（覆盖其全部重试）生成一个关联日志与 TaskRun 的 trace ID
+func newTraceID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("trc-%d", time.Now().UnixNano())
+	}
+	return "trc-" + hex.EncodeToString(b[:])
+}
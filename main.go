@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -12,32 +15,107 @@ import (
 
 // Task 数据库任务模型
 type Task struct {
-	ID      uint   `gorm:"primaryKey"`
-	Name    string `gorm:"not null"`
-	Program string `gorm:"not null"`
-	Cron    string `gorm:"not null"`
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null"`
+	Program   string `gorm:"not null"`
+	Cron      string `gorm:"not null"`
+	Type      string `gorm:"not null;default:shell"` // 执行器类型：shell、http、plugin...
+	Args      string `gorm:"type:text"`              // 执行器参数，JSON 格式，具体结构由 Type 决定
+	SpecifyIP string `gorm:"column:specify_ip"`      // 非空时仅允许 IP 匹配的副本执行该任务
+
+	MaxRetries   int    `gorm:"not null;default:0"` // 失败后的最大重试次数
+	RetryBackoff string `gorm:"default:'1s'"`       // time.ParseDuration 格式，重试退避的基准间隔，第 n 次重试前等待 RetryBackoff*2^(n-1)
+	Timeout      string `gorm:"default:'30s'"`      // time.ParseDuration 格式，单次执行的超时时间
+	Concurrency  string `gorm:"default:skip"`       // 并发策略：skip（跳过）、queue（排队）、allow-parallel（允许并行）
+
+	UpdatedAt time.Time // GORM 自动维护，热加载循环据此判断任务是否发生变更（见 WithReloadInterval）
 }
 
 // CronScheduler 封装 cron 调度器和任务映射
 type CronScheduler struct {
-	cron    *cron.Cron
-	taskIDs map[uint]cron.EntryID // 映射数据库任务 ID 到 cron 任务 ID
-	db      *gorm.DB
+	cron        *cron.Cron
+	db          *gorm.DB
+	executors   map[string]Executor // 任务 Type 到 Executor 实现的注册表
+	coordinator Coordinator         // 可选的分布式协调后端，用于多副本部署下的 leader 选举
+	localIP     string              // 本副本 IP，用于匹配 Task.SpecifyIP
+
+	runMu        sync.Mutex           // 保护 runningTasks / taskLocks
+	runningTasks map[uint]bool        // 正在执行中的任务 ID，用于 Concurrency=skip 的去重
+	taskLocks    map[uint]*sync.Mutex // 每个任务一把锁，用于 Concurrency=queue 的排队执行
+
+	taskMu  sync.Mutex         // 保护 taskIDs，热加载循环与 AddTask/RemoveTask 可能并发访问
+	taskIDs map[uint]taskEntry // 映射数据库任务 ID 到其当前 cron 条目及热加载判重所需的快照
+
+	reloadInterval time.Duration // 热加载轮询周期，0 表示不启用（见 WithReloadInterval）
+	reloadStop     chan struct{} // 关闭热加载循环的信号
+	stopOnce       sync.Once     // 保证 Stop 可安全多次调用
+
+	logger Logger // 日志输出目标，默认为 stdLogger，可通过 WithLogger 替换
+
+	httpMiddleware func(http.Handler) http.Handler // 包裹 HTTPHandler 的中间件，用于鉴权等，见 WithHTTPMiddleware
 }
 
-// NewCronScheduler 初始化调度器
-func NewCronScheduler(db *gorm.DB) *CronScheduler {
+// NewCronScheduler 初始化调度器，可通过 Option（如 WithCoordinator、WithLocalIP）扩展可选依赖
+func NewCronScheduler(db *gorm.DB, opts ...Option) *CronScheduler {
 	// 设置上海时区
 	loc, err := time.LoadLocation("Asia/Shanghai")
 	if err != nil {
 		log.Fatalf("加载上海时区失败: %v", err)
 	}
 
-	return &CronScheduler{
-		cron:    cron.New(cron.WithLocation(loc), cron.WithSeconds()),
-		taskIDs: make(map[uint]cron.EntryID),
-		db:      db,
+	cs := &CronScheduler{
+		cron:         cron.New(cron.WithLocation(loc), cron.WithSeconds()),
+		db:           db,
+		executors:    make(map[string]Executor),
+		localIP:      detectLocalIP(),
+		runningTasks: make(map[uint]bool),
+		taskLocks:    make(map[uint]*sync.Mutex),
+		taskIDs:      make(map[uint]taskEntry),
+		reloadStop:   make(chan struct{}),
+		logger:       stdLogger{},
+	}
+
+	// 内置 shell 和 http 执行器，用户可通过 RegisterExecutor 覆盖或扩展
+	cs.RegisterExecutor("shell", ShellExecutor{})
+	cs.RegisterExecutor("http", HTTPExecutor{})
+
+	for _, opt := range opts {
+		opt(cs)
+	}
+
+	return cs
+}
+
+// RegisterExecutor 注册（或覆盖）指定 Type 对应的执行器，使调用方无需 fork 本仓库即可接入新的任务类型
+func (cs *CronScheduler) RegisterExecutor(taskType string, executor Executor) {
+	cs.executors[taskType] = executor
+}
+
+// executeOnce 按照 task.Type 分派给对应的 Executor 执行任务一次，不处理重试或记录
+func (cs *CronScheduler) executeOnce(ctx context.Context, task Task) (string, error) {
+	executor, ok := cs.executors[task.Type]
+	if !ok {
+		return "", fmt.Errorf("未知的任务类型 %q", task.Type)
+	}
+	return executor.Execute(ctx, task)
+}
+
+// scheduleTask 将 task 注册为一个 cron 条目，并记录/覆盖 taskIDs 中对应的 taskEntry 快照，
+// 供 RemoveTask 和热加载循环（见 reload.go）判断任务是否已变更
+func (cs *CronScheduler) scheduleTask(task Task) error {
+	entryID, err := cs.cron.AddFunc(task.Cron, func() {
+		traceID := newTraceID()
+		scheduledAt := cs.scheduledTimeFor(task.ID)
+		cs.runWithCoordination(task, traceID, scheduledAt, func() { cs.executeWithTracking(task, traceID) })
+	})
+	if err != nil {
+		return err
 	}
+
+	cs.taskMu.Lock()
+	cs.taskIDs[task.ID] = taskEntry{EntryID: entryID, Cron: task.Cron, UpdatedAt: task.UpdatedAt}
+	cs.taskMu.Unlock()
+	return nil
 }
 
 // StartAllTasks 启动数据库中的所有任务
@@ -49,50 +127,49 @@ func (cs *CronScheduler) StartAllTasks() error {
 	}
 
 	for _, task := range tasks {
-		// 添加任务到调度器
-		entryID, err := cs.cron.AddFunc(task.Cron, func() {
-			fmt.Printf("执行任务 %s (%d): %s\n", task.Name, task.ID, task.Program)
-		})
-		if err != nil {
-			log.Printf("添加任务 %s (%d) 失败: %v", task.Name, task.ID, err)
+		task := task
+		if err := cs.scheduleTask(task); err != nil {
+			cs.logger.Error("添加任务失败", "task_id", task.ID, "task_name", task.Name, "err", err)
 			continue
 		}
-
-		// 记录任务 ID 映射
-		cs.taskIDs[task.ID] = entryID
-		log.Printf("任务 %s (%d) 已启动，cron: %s", task.Name, task.ID, task.Cron)
+		cs.logger.Info("任务已启动", "task_id", task.ID, "task_name", task.Name, "cron", task.Cron)
 	}
 
 	// 启动调度器
 	cs.cron.Start()
+
+	// 如配置了热加载轮询周期，启动后台协程定期对账 tasks 表
+	if cs.reloadInterval > 0 {
+		cs.startReloadLoop()
+	}
 	return nil
 }
 
-// AddTask 添加新任务
-func (cs *CronScheduler) AddTask(name, program, cronExpr string) error {
+// AddTask 添加新任务，taskType 为空时默认使用 shell 执行器
+func (cs *CronScheduler) AddTask(name, program, cronExpr, taskType, args string) error {
+	if taskType == "" {
+		taskType = "shell"
+	}
 	// 创建任务记录
 	task := Task{
 		Name:    name,
 		Program: program,
 		Cron:    cronExpr,
+		Type:    taskType,
+		Args:    args,
 	}
 	if err := cs.db.Create(&task).Error; err != nil {
 		return fmt.Errorf("创建任务失败: %v", err)
 	}
 
 	// 添加到调度器
-	entryID, err := cs.cron.AddFunc(cronExpr, func() {
-		fmt.Printf("执行任务 %s (%d): %s\n", task.Name, task.ID, task.Program)
-	})
-	if err != nil {
+	if err := cs.scheduleTask(task); err != nil {
 		// 如果添加失败，删除数据库记录
 		cs.db.Delete(&task)
 		return fmt.Errorf("添加任务到调度器失败: %v", err)
 	}
 
-	// 记录任务 ID 映射
-	cs.taskIDs[task.ID] = entryID
-	log.Printf("任务 %s (%d) 已添加，cron: %s", task.Name, task.ID, cronExpr)
+	cs.logger.Info("任务已添加", "task_id", task.ID, "task_name", task.Name, "cron", cronExpr)
 	return nil
 }
 
@@ -105,17 +182,19 @@ func (cs *CronScheduler) RemoveTask(taskID uint) error {
 	}
 
 	// 从调度器中移除
-	if entryID, exists := cs.taskIDs[taskID]; exists {
-		cs.cron.Remove(entryID)
+	cs.taskMu.Lock()
+	if entry, exists := cs.taskIDs[taskID]; exists {
+		cs.cron.Remove(entry.EntryID)
 		delete(cs.taskIDs, taskID)
 	}
+	cs.taskMu.Unlock()
 
 	// 从数据库中删除
 	if err := cs.db.Delete(&Task{}, taskID).Error; err != nil {
 		return fmt.Errorf("删除任务 %d 失败: %v", taskID, err)
 	}
 
-	log.Printf("任务 %s (%d) 已删除", task.Name, taskID)
+	cs.logger.Info("任务已删除", "task_id", taskID, "task_name", task.Name)
 	return nil
 }
 
@@ -129,15 +208,15 @@ func initDB() *gorm.DB {
 	}
 
 	// 自动迁移数据库表
-	if err := db.AutoMigrate(&Task{}); err != nil {
+	if err := db.AutoMigrate(&Task{}, &TaskRun{}); err != nil {
 		log.Fatalf("迁移数据库表失败: %v", err)
 	}
 
 	// 插入示例数据
 	tasks := []Task{
-		{Name: "DailyBackup", Program: "运行数据库备份脚本", Cron: "0 0 0 * * *"},     // 每天午夜
-		{Name: "HourlyCheck", Program: "检查系统状态", Cron: "0 0 * * * *"},        // 每小时
-		{Name: "BiMinuteReport", Program: "生成每两分钟报告", Cron: "0 */2 * * * *"}, // 每两分钟
+		{Name: "DailyBackup", Program: "echo", Args: `{"args":["running-backup"]}`, Cron: "0 0 0 * * *", Type: "shell"},         // 每天午夜
+		{Name: "HourlyCheck", Program: "https://example.com/healthz", Cron: "0 0 * * * *", Type: "http"},                        // 每小时
+		{Name: "BiMinuteReport", Program: "echo", Args: `{"args":["generating-report"]}`, Cron: "0 */2 * * * *", Type: "shell"}, // 每两分钟
 	}
 	for _, task := range tasks {
 		// 仅插入不存在的任务
@@ -173,7 +252,7 @@ func main() {
 		time.Sleep(10 * time.Second)
 
 		// 添加新任务
-		err := scheduler.AddTask("TestTask", "运行测试程序", "0 * * * * *") // 每分钟
+		err := scheduler.AddTask("TestTask", "echo", "0 * * * * *", "shell", `{"args":["test-task"]}`) // 每分钟
 		if err != nil {
 			log.Printf("添加任务失败: %v", err)
 		}
@@ -0,0 +1,4 @@
+package main
+
+// Option 是 NewCronScheduler 的功能性选项，用于在不破坏现有调用方式的前提下扩展调度器的可选依赖
+type Option func(cs *CronScheduler)
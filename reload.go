@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// taskEntry 记录某个任务当前注册的 cron 条目 ID、注册时的 Cron 表达式，以及热加载判重所用的 UpdatedAt 快照
+type taskEntry struct {
+	EntryID   cron.EntryID
+	Cron      string
+	UpdatedAt time.Time
+}
+
+// WithReloadInterval 启用后台任务热加载：每隔 interval 对比数据库 tasks 表与内存中的 taskIDs，
+// 自动添加新增任务、移除已删除任务、重新加载 UpdatedAt 发生变化的任务（如 Cron 表达式被修改）。
+// interval <= 0（默认值）表示不启用热加载。
+func WithReloadInterval(interval time.Duration) Option {
+	return func(cs *CronScheduler) {
+		cs.reloadInterval = interval
+	}
+}
+
+// Stop 停止底层 cron 调度器并阻塞等待正在执行的任务结束，同时关闭热加载循环（如果已启动）；
+// 可安全多次调用，供把本模块嵌入长期运行服务的调用方在退出时做优雅关闭
+func (cs *CronScheduler) Stop() {
+	cs.stopOnce.Do(func() {
+		<-cs.cron.Stop().Done()
+		if cs.reloadInterval > 0 {
+			close(cs.reloadStop)
+		}
+	})
+}
+
+// startReloadLoop 按 reloadInterval 周期性调用 ReloadNow，直到 reloadStop 被关闭；
+// 由 StartAllTasks 在设置了 WithReloadInterval 时自动启动
+func (cs *CronScheduler) startReloadLoop() {
+	ticker := time.NewTicker(cs.reloadInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := cs.ReloadNow(); err != nil {
+					cs.logger.Error("定时重新加载任务失败", "err", err)
+				}
+			case <-cs.reloadStop:
+				return
+			}
+		}
+	}()
+}
+
+// ReloadNow 立即对比数据库 tasks 表与内存状态并执行一次热加载：新增任务会被添加，已从数据库删除
+// 的任务会被移除，UpdatedAt 发生变化的任务会被重新加载（先移除旧 cron 条目，再按最新 Cron 表达式
+// 重新注册）。可供外部系统在修改 tasks 表后主动调用，不必等待下一次定时轮询。
+func (cs *CronScheduler) ReloadNow() error {
+	var tasks []Task
+	if err := cs.db.Find(&tasks).Error; err != nil {
+		return fmt.Errorf("重新加载任务失败: %v", err)
+	}
+
+	seen := make(map[uint]bool, len(tasks))
+	for _, task := range tasks {
+		task := task
+		seen[task.ID] = true
+
+		cs.taskMu.Lock()
+		entry, exists := cs.taskIDs[task.ID]
+		cs.taskMu.Unlock()
+
+		if !exists {
+			if err := cs.scheduleTask(task); err != nil {
+				cs.logger.Error("热加载：添加任务失败", "task_id", task.ID, "task_name", task.Name, "err", err)
+				continue
+			}
+			cs.logger.Info("热加载：新增任务", "task_id", task.ID, "task_name", task.Name, "cron", task.Cron)
+			continue
+		}
+
+		if task.UpdatedAt.Equal(entry.UpdatedAt) {
+			continue
+		}
+
+		cs.cron.Remove(entry.EntryID)
+		if err := cs.scheduleTask(task); err != nil {
+			cs.logger.Error("热加载：重新加载任务失败", "task_id", task.ID, "task_name", task.Name, "err", err)
+			continue
+		}
+		cs.logger.Info("热加载：任务已变更，重新加载", "task_id", task.ID, "task_name", task.Name, "cron", task.Cron)
+	}
+
+	cs.taskMu.Lock()
+	var stale []uint
+	for id := range cs.taskIDs {
+		if !seen[id] {
+			stale = append(stale, id)
+		}
+	}
+	for _, id := range stale {
+		cs.cron.Remove(cs.taskIDs[id].EntryID)
+		delete(cs.taskIDs, id)
+	}
+	cs.taskMu.Unlock()
+
+	for _, id := range stale {
+		cs.logger.Info("热加载：任务已从数据库删除，移除 cron 条目", "task_id", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestStop_ShutsDownReloadLoop 确认配置了 WithReloadInterval 后，Stop 会关闭 reloadStop，
+// 使 startReloadLoop 对应的后台协程退出，而不是无限期运行
+func TestStop_ShutsDownReloadLoop(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&Task{}, &TaskRun{}); err != nil {
+		t.Fatalf("迁移数据库表失败: %v", err)
+	}
+
+	cs := NewCronScheduler(db, WithReloadInterval(time.Millisecond))
+	if err := cs.StartAllTasks(); err != nil {
+		t.Fatalf("StartAllTasks 失败: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cs.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop 未能在预期时间内完成，热加载循环可能没有退出")
+	}
+
+	// reloadStop 被关闭后应立即处于已关闭状态，再次读取不会阻塞
+	select {
+	case <-cs.reloadStop:
+	default:
+		t.Fatal("期望 Stop 之后 reloadStop 已被关闭")
+	}
+}
+
+// TestStop_WithoutReloadInterval 确认未启用热加载时 Stop 正常返回，且不会关闭 reloadStop
+func TestStop_WithoutReloadInterval(t *testing.T) {
+	cs := &CronScheduler{cron: cron.New(cron.WithSeconds()), reloadStop: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cs.Stop()
+	}()
+	wg.Wait()
+
+	select {
+	case <-cs.reloadStop:
+		t.Fatal("未启用热加载时 Stop 不应关闭 reloadStop")
+	default:
+	}
+}
+
+// TestStop_Idempotent 确认重复调用 Stop 不会因为重复 close(reloadStop) 而 panic
+func TestStop_Idempotent(t *testing.T) {
+	cs := &CronScheduler{
+		cron:           cron.New(cron.WithSeconds()),
+		reloadStop:     make(chan struct{}),
+		reloadInterval: time.Second,
+	}
+
+	cs.Stop()
+	cs.Stop()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TaskRun 记录一次任务执行的状态和结果，每次触发（包括每次重试）都会写入一行
+type TaskRun struct {
+	ID         uint `gorm:"primaryKey"`
+	TaskID     uint `gorm:"index;not null"`
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     string // running / success / failed，见 TaskRunStatus* 常量
+	Output     string `gorm:"type:text"`
+	Error      string `gorm:"type:text"`
+	Attempt    int    // 第几次尝试，从 1 开始
+	TraceID    string `gorm:"index"` // 本次触发（含其全部重试）共用的关联 ID，用于跨日志定位
+}
+
+// TaskRun.Status 取值
+const (
+	TaskRunStatusRunning = "running"
+	TaskRunStatusSuccess = "success"
+	TaskRunStatusFailed  = "failed"
+)
+
+// Task.Concurrency 取值
+const (
+	ConcurrencySkip          = "skip"           // 上一次还未结束时，跳过本次触发
+	ConcurrencyQueue         = "queue"          // 排队等待上一次结束后再执行
+	ConcurrencyAllowParallel = "allow-parallel" // 允许与上一次并行执行
+)
+
+const (
+	defaultRetryBackoff = time.Second
+	defaultTimeout      = 30 * time.Second
+)
+
+// ListRuns 返回指定任务最近的 limit 条执行记录，按开始时间倒序
+func (cs *CronScheduler) ListRuns(taskID uint, limit int) ([]TaskRun, error) {
+	var runs []TaskRun
+	query := cs.db.Where("task_id = ?", taskID).Order("started_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// LastRun 返回指定任务最近一次的执行记录，不存在时返回 gorm.ErrRecordNotFound
+func (cs *CronScheduler) LastRun(taskID uint) (TaskRun, error) {
+	var run TaskRun
+	err := cs.db.Where("task_id = ?", taskID).Order("started_at DESC").First(&run).Error
+	return run, err
+}
+
+// taskLock 返回（必要时创建）指定任务在 Concurrency=queue 模式下使用的互斥锁
+func (cs *CronScheduler) taskLock(taskID uint) *sync.Mutex {
+	cs.runMu.Lock()
+	defer cs.runMu.Unlock()
+	if lock, ok := cs.taskLocks[taskID]; ok {
+		return lock
+	}
+	lock := &sync.Mutex{}
+	cs.taskLocks[taskID] = lock
+	return lock
+}
+
+// executeWithTracking 按照 task 的并发策略、超时和重试配置执行一次任务触发，并把每次尝试记录到 TaskRun；
+// traceID 由调用方（cron 回调）为本次触发生成，所有重试共用同一个 traceID
+func (cs *CronScheduler) executeWithTracking(task Task, traceID string) {
+	switch task.Concurrency {
+	case ConcurrencyAllowParallel:
+		// 不做任何去重/排队处理
+	case ConcurrencyQueue:
+		lock := cs.taskLock(task.ID)
+		lock.Lock()
+		defer lock.Unlock()
+	default: // ConcurrencySkip 及空值
+		cs.runMu.Lock()
+		if cs.runningTasks[task.ID] {
+			cs.runMu.Unlock()
+			cs.logger.Info("上一次执行尚未结束，跳过本次触发", "task_id", task.ID, "task_name", task.Name, "trace_id", traceID)
+			return
+		}
+		cs.runningTasks[task.ID] = true
+		cs.runMu.Unlock()
+		defer func() {
+			cs.runMu.Lock()
+			delete(cs.runningTasks, task.ID)
+			cs.runMu.Unlock()
+		}()
+	}
+
+	timeout := parseDurationOr(task.Timeout, defaultTimeout)
+	backoff := parseDurationOr(task.RetryBackoff, defaultRetryBackoff)
+	maxAttempts := task.MaxRetries + 1
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if cs.runAttempt(task, attempt, timeout, traceID) {
+			return
+		}
+		if attempt < maxAttempts {
+			time.Sleep(backoff * (1 << uint(attempt-1)))
+		}
+	}
+}
+
+// runAttempt 执行一次尝试，记录 TaskRun，返回是否成功
+func (cs *CronScheduler) runAttempt(task Task, attempt int, timeout time.Duration, traceID string) bool {
+	run := TaskRun{
+		TaskID:    task.ID,
+		StartedAt: time.Now(),
+		Status:    TaskRunStatusRunning,
+		Attempt:   attempt,
+		TraceID:   traceID,
+	}
+	if err := cs.db.Create(&run).Error; err != nil {
+		cs.logger.Error("记录执行开始失败", "task_id", task.ID, "task_name", task.Name, "trace_id", traceID, "err", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx = withTraceID(ctx, traceID)
+
+	output, err := cs.executeOnce(ctx, task)
+
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Status = TaskRunStatusFailed
+		run.Error = err.Error()
+		cs.logger.Warn("尝试执行失败", "task_id", task.ID, "task_name", task.Name, "trace_id", traceID, "attempt", attempt, "err", err)
+	} else {
+		run.Status = TaskRunStatusSuccess
+		run.Output = output
+	}
+
+	if run.ID != 0 {
+		cs.db.Save(&run)
+	}
+	return err == nil
+}
+
+// parseDurationOr 解析 s，解析失败或为空时返回 fallback
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	if s == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
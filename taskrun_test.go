@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTaskrunTestDB 建立一个内存 sqlite 库并迁移 TaskRun，供 executeWithTracking 落库
+func newTaskrunTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&TaskRun{}); err != nil {
+		t.Fatalf("迁移 TaskRun 失败: %v", err)
+	}
+	return db
+}
+
+// newTestScheduler 构造一个只填充了 executeWithTracking 所需字段的 CronScheduler
+func newTestScheduler(t *testing.T) *CronScheduler {
+	t.Helper()
+	return &CronScheduler{
+		db:           newTaskrunTestDB(t),
+		logger:       NoopLogger{},
+		executors:    make(map[string]Executor),
+		runningTasks: make(map[uint]bool),
+		taskLocks:    make(map[uint]*sync.Mutex),
+	}
+}
+
+// countingExecutor 记录调用次数，前 failTimes 次返回错误，之后返回成功
+type countingExecutor struct {
+	mu        sync.Mutex
+	calls     int
+	failTimes int
+}
+
+func (e *countingExecutor) Execute(ctx context.Context, task Task) (string, error) {
+	e.mu.Lock()
+	e.calls++
+	n := e.calls
+	e.mu.Unlock()
+
+	if n <= e.failTimes {
+		return "", fmt.Errorf("模拟第 %d 次调用失败", n)
+	}
+	return "ok", nil
+}
+
+func TestExecuteWithTracking_RetriesUntilSuccess(t *testing.T) {
+	cs := newTestScheduler(t)
+	exec := &countingExecutor{failTimes: 2}
+	cs.RegisterExecutor("counting", exec)
+
+	task := Task{ID: 1, Name: "retry-task", Type: "counting", MaxRetries: 3, RetryBackoff: "5ms", Timeout: "1s"}
+	cs.executeWithTracking(task, "trace-1")
+
+	exec.mu.Lock()
+	calls := exec.calls
+	exec.mu.Unlock()
+	if calls != 3 {
+		t.Fatalf("期望重试到第 3 次成功，实际调用了 %d 次", calls)
+	}
+
+	runs, err := cs.ListRuns(task.ID, 0)
+	if err != nil {
+		t.Fatalf("ListRuns 失败: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("期望记录 3 条 TaskRun，实际 %d 条", len(runs))
+	}
+
+	last, err := cs.LastRun(task.ID)
+	if err != nil {
+		t.Fatalf("LastRun 失败: %v", err)
+	}
+	if last.Status != TaskRunStatusSuccess {
+		t.Fatalf("期望最后一次状态为 success，实际 %q", last.Status)
+	}
+}
+
+func TestExecuteWithTracking_ExhaustsRetriesAndStaysFailed(t *testing.T) {
+	cs := newTestScheduler(t)
+	exec := &countingExecutor{failTimes: 100}
+	cs.RegisterExecutor("counting", exec)
+
+	task := Task{ID: 2, Name: "always-fail", Type: "counting", MaxRetries: 2, RetryBackoff: "1ms", Timeout: "1s"}
+	cs.executeWithTracking(task, "trace-2")
+
+	exec.mu.Lock()
+	calls := exec.calls
+	exec.mu.Unlock()
+	if calls != 3 { // 1 次初始尝试 + 2 次重试
+		t.Fatalf("期望总共尝试 3 次，实际 %d 次", calls)
+	}
+
+	last, err := cs.LastRun(task.ID)
+	if err != nil {
+		t.Fatalf("LastRun 失败: %v", err)
+	}
+	if last.Status != TaskRunStatusFailed {
+		t.Fatalf("期望最后一次状态为 failed，实际 %q", last.Status)
+	}
+}
+
+// blockingExecutor 阻塞到 release 被关闭为止，并记录观察到的最大并发执行数，
+// 用来验证 Concurrency 策略是否按预期去重/排队/放行
+type blockingExecutor struct {
+	release     chan struct{}
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (e *blockingExecutor) Execute(ctx context.Context, task Task) (string, error) {
+	n := atomic.AddInt32(&e.inFlight, 1)
+	for {
+		old := atomic.LoadInt32(&e.maxInFlight)
+		if n <= old {
+			break
+		}
+		if atomic.CompareAndSwapInt32(&e.maxInFlight, old, n) {
+			break
+		}
+	}
+	<-e.release
+	atomic.AddInt32(&e.inFlight, -1)
+	return "ok", nil
+}
+
+// waitForInFlight 轮询直到 inFlight 达到 n，超时则让测试失败
+func waitForInFlight(t *testing.T, exec *blockingExecutor, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if atomic.LoadInt32(&exec.inFlight) >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("等待 inFlight >= %d 超时", n)
+}
+
+func TestExecuteWithTracking_SkipConcurrencyDropsOverlappingTrigger(t *testing.T) {
+	cs := newTestScheduler(t)
+	exec := &blockingExecutor{release: make(chan struct{})}
+	cs.RegisterExecutor("blocking", exec)
+
+	task := Task{ID: 3, Name: "skip-task", Type: "blocking", Concurrency: ConcurrencySkip, Timeout: "5s"}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cs.executeWithTracking(task, "trace-first")
+	}()
+
+	// 等第一次触发进入执行后再发起第二次，Concurrency=skip 下第二次应直接跳过而不是排队等待
+	waitForInFlight(t, exec, 1)
+	cs.executeWithTracking(task, "trace-second")
+
+	close(exec.release)
+	wg.Wait()
+
+	runs, err := cs.ListRuns(task.ID, 0)
+	if err != nil {
+		t.Fatalf("ListRuns 失败: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("Concurrency=skip 时期望只记录 1 次执行，实际 %d 次", len(runs))
+	}
+	if atomic.LoadInt32(&exec.maxInFlight) != 1 {
+		t.Fatalf("期望任何时刻最多 1 次并发执行，实际观察到 %d", exec.maxInFlight)
+	}
+}
+
+func TestExecuteWithTracking_QueueConcurrencyRunsSerially(t *testing.T) {
+	cs := newTestScheduler(t)
+	exec := &blockingExecutor{release: make(chan struct{})}
+	cs.RegisterExecutor("blocking", exec)
+
+	task := Task{ID: 4, Name: "queue-task", Type: "blocking", Concurrency: ConcurrencyQueue, Timeout: "5s"}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		cs.executeWithTracking(task, "trace-a")
+	}()
+	waitForInFlight(t, exec, 1)
+
+	go func() {
+		defer wg.Done()
+		cs.executeWithTracking(task, "trace-b")
+	}()
+
+	// 排队模式下，第二次触发必须在第一次结束前保持等待，不应提前进入执行
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&exec.inFlight) != 1 {
+		t.Fatalf("Concurrency=queue 时第二次触发不应在第一次结束前开始执行")
+	}
+
+	close(exec.release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&exec.maxInFlight) != 1 {
+		t.Fatalf("Concurrency=queue 时期望两次触发串行执行，实际观察到最大并发 %d", exec.maxInFlight)
+	}
+
+	runs, err := cs.ListRuns(task.ID, 0)
+	if err != nil {
+		t.Fatalf("ListRuns 失败: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("期望记录 2 次执行，实际 %d 次", len(runs))
+	}
+}
+
+func TestExecuteWithTracking_AllowParallelRunsConcurrently(t *testing.T) {
+	cs := newTestScheduler(t)
+	exec := &blockingExecutor{release: make(chan struct{})}
+	cs.RegisterExecutor("blocking", exec)
+
+	task := Task{ID: 5, Name: "parallel-task", Type: "blocking", Concurrency: ConcurrencyAllowParallel, Timeout: "5s"}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		cs.executeWithTracking(task, "trace-a")
+	}()
+	go func() {
+		defer wg.Done()
+		cs.executeWithTracking(task, "trace-b")
+	}()
+
+	waitForInFlight(t, exec, 2)
+	close(exec.release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&exec.maxInFlight) != 2 {
+		t.Fatalf("Concurrency=allow-parallel 时期望观察到 2 次并发执行，实际最大 %d", exec.maxInFlight)
+	}
+}